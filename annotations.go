@@ -0,0 +1,301 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strings"
+)
+
+// annotationColors maps the leading colour letter used by Lichess's %cal /
+// %csl PGN comment syntax to the RGBA it is drawn with.
+var annotationColors = map[byte]color.RGBA{
+	'G': {R: 0x15, G: 0x78, B: 0x1b, A: 0xb3},
+	'R': {R: 0x88, G: 0x20, B: 0x20, A: 0xb3},
+	'Y': {R: 0xe6, G: 0x8f, B: 0x00, A: 0xb3},
+	'B': {R: 0x00, G: 0x3f, B: 0x88, A: 0xb3},
+}
+
+// Circle is a single-square annotation parsed from ?circles=, e.g. "Ye5".
+type Circle struct {
+	Square string
+	Color  color.RGBA
+}
+
+// Arrow is a from/to annotation parsed from ?arrows=, e.g. "Gg1f3".
+type Arrow struct {
+	From, To string
+	Color    color.RGBA
+}
+
+// parseCircles parses the ?circles= query parameter (e.g. "Ye5,Gd4"),
+// matching Lichess's %csl PGN comment syntax: a leading colour letter (G
+// green, R red, Y yellow, B blue) followed by one square.
+func parseCircles(param string) ([]Circle, error) {
+	if param == "" {
+		return nil, nil
+	}
+
+	var circles []Circle
+	for _, token := range strings.Split(param, ",") {
+		c, err := parseAnnotationColor(token)
+		if err != nil {
+			return nil, err
+		}
+
+		square := token[1:]
+		if _, _, err := squareToCoords(square); err != nil {
+			return nil, fmt.Errorf("invalid circle %q: %w", token, err)
+		}
+
+		circles = append(circles, Circle{Square: square, Color: c})
+	}
+
+	return circles, nil
+}
+
+// parseArrows parses the ?arrows= query parameter (e.g. "Gg1f3,Re4e5"),
+// matching Lichess's %cal PGN comment syntax: a leading colour letter
+// followed by a from/to square pair.
+func parseArrows(param string) ([]Arrow, error) {
+	if param == "" {
+		return nil, nil
+	}
+
+	var arrows []Arrow
+	for _, token := range strings.Split(param, ",") {
+		c, err := parseAnnotationColor(token)
+		if err != nil {
+			return nil, err
+		}
+
+		squares := token[1:]
+		if len(squares) != 4 {
+			return nil, fmt.Errorf("invalid arrow %q: expected a colour and a from/to square pair, e.g. Gg1f3", token)
+		}
+
+		from, to := squares[:2], squares[2:]
+		if _, _, err := squareToCoords(from); err != nil {
+			return nil, fmt.Errorf("invalid arrow %q: %w", token, err)
+		}
+		if _, _, err := squareToCoords(to); err != nil {
+			return nil, fmt.Errorf("invalid arrow %q: %w", token, err)
+		}
+
+		arrows = append(arrows, Arrow{From: from, To: to, Color: c})
+	}
+
+	return arrows, nil
+}
+
+func parseAnnotationColor(token string) (color.RGBA, error) {
+	if len(token) < 2 {
+		return color.RGBA{}, fmt.Errorf("invalid annotation %q", token)
+	}
+
+	c, ok := annotationColors[token[0]]
+	if !ok {
+		return color.RGBA{}, fmt.Errorf("invalid annotation colour %q in %q", string(token[0]), token)
+	}
+
+	return c, nil
+}
+
+// drawAnnotations draws circles then arrows over the board, in screen
+// space, alpha-blending into the existing pixels so pieces remain visible
+// underneath.
+func drawAnnotations(img *image.RGBA, circles []Circle, arrows []Arrow, flip bool, tileSize int) {
+	for _, circle := range circles {
+		column, row, err := squareToCoords(circle.Square)
+		if err != nil {
+			continue
+		}
+
+		screenColumn, screenRow := toScreen(column, row, flip)
+		drawCircleOutline(img, screenColumn, screenRow, tileSize, circle.Color)
+	}
+
+	for _, arrow := range arrows {
+		fromColumn, fromRow, err := squareToCoords(arrow.From)
+		if err != nil {
+			continue
+		}
+
+		toColumn, toRow, err := squareToCoords(arrow.To)
+		if err != nil {
+			continue
+		}
+
+		fsc, fsr := toScreen(fromColumn, fromRow, flip)
+		tsc, tsr := toScreen(toColumn, toRow, flip)
+		drawArrow(img, fsc, fsr, tsc, tsr, tileSize, arrow.Color)
+	}
+}
+
+func tileCenter(screenColumn, screenRow, tileSize int) (x, y float64) {
+	return float64(screenColumn*tileSize) + float64(tileSize)/2, float64(screenRow*tileSize) + float64(tileSize)/2
+}
+
+// drawCircleOutline draws an anti-aliased ring around the given tile.
+func drawCircleOutline(img *image.RGBA, screenColumn, screenRow, tileSize int, c color.RGBA) {
+	cx, cy := tileCenter(screenColumn, screenRow, tileSize)
+	radius := float64(tileSize) * 0.44
+	thickness := float64(tileSize) * 0.06
+
+	forEachPixelNear(img, cx, cy, radius+thickness, func(x, y int, px, py float64) {
+		dist := math.Hypot(px-cx, py-cy)
+		if coverage := ringCoverage(dist, radius, thickness); coverage > 0 {
+			blendPixel(img, x, y, c, coverage)
+		}
+	})
+}
+
+func ringCoverage(dist, radius, thickness float64) float64 {
+	edge := math.Abs(dist - radius)
+	half := thickness / 2
+	switch {
+	case edge >= half+0.5:
+		return 0
+	case edge <= half-0.5:
+		return 1
+	default:
+		return half + 0.5 - edge
+	}
+}
+
+// drawArrow draws an alpha-blended shaft with a filled triangular head
+// pointing from the center of the from tile to the center of the to tile.
+func drawArrow(img *image.RGBA, fromScreenColumn, fromScreenRow, toScreenColumn, toScreenRow, tileSize int, c color.RGBA) {
+	x0, y0 := tileCenter(fromScreenColumn, fromScreenRow, tileSize)
+	x1, y1 := tileCenter(toScreenColumn, toScreenRow, tileSize)
+
+	length := math.Hypot(x1-x0, y1-y0)
+	if length == 0 {
+		return
+	}
+
+	ux, uy := (x1-x0)/length, (y1-y0)/length
+	headLength := float64(tileSize) * 0.35
+	headWidth := float64(tileSize) * 0.28
+	shaftWidth := float64(tileSize) * 0.12
+
+	// Start a little way into the source tile and stop where the
+	// arrowhead begins, so the shaft doesn't poke out past the tip.
+	startX, startY := x0+ux*float64(tileSize)*0.15, y0+uy*float64(tileSize)*0.15
+	shaftEndX, shaftEndY := x1-ux*headLength, y1-uy*headLength
+
+	margin := float64(tileSize)
+	minX := math.Min(x0, x1) - margin
+	maxX := math.Max(x0, x1) + margin
+	minY := math.Min(y0, y1) - margin
+	maxY := math.Max(y0, y1) + margin
+
+	forEachPixelInBounds(img, minX, minY, maxX, maxY, func(x, y int, px, py float64) {
+		if coverage := segmentCoverage(px, py, startX, startY, shaftEndX, shaftEndY, shaftWidth); coverage > 0 {
+			blendPixel(img, x, y, c, coverage)
+			return
+		}
+
+		if coverage := triangleCoverage(px, py, x1, y1, ux, uy, headLength, headWidth); coverage > 0 {
+			blendPixel(img, x, y, c, coverage)
+		}
+	})
+}
+
+// segmentCoverage returns how much the pixel at (px, py) is covered by a
+// line segment of the given width, with a roughly 1px anti-aliased edge.
+func segmentCoverage(px, py, x0, y0, x1, y1, width float64) float64 {
+	dist := distanceToSegment(px, py, x0, y0, x1, y1)
+	half := width / 2
+	switch {
+	case dist >= half+0.5:
+		return 0
+	case dist <= half-0.5:
+		return 1
+	default:
+		return half + 0.5 - dist
+	}
+}
+
+func distanceToSegment(px, py, x0, y0, x1, y1 float64) float64 {
+	dx, dy := x1-x0, y1-y0
+	lengthSq := dx*dx + dy*dy
+	if lengthSq == 0 {
+		return math.Hypot(px-x0, py-y0)
+	}
+
+	t := ((px-x0)*dx + (py-y0)*dy) / lengthSq
+	t = math.Max(0, math.Min(1, t))
+
+	closestX, closestY := x0+t*dx, y0+t*dy
+	return math.Hypot(px-closestX, py-closestY)
+}
+
+// triangleCoverage approximates coverage of the arrowhead triangle whose
+// tip is at (tipX, tipY), pointing along (ux, uy), with the given length
+// and base width.
+func triangleCoverage(px, py, tipX, tipY, ux, uy, length, width float64) float64 {
+	relX, relY := px-tipX, py-tipY
+	along := -(relX*ux + relY*uy)
+	if along < -0.5 || along > length+0.5 {
+		return 0
+	}
+
+	perp := math.Abs(relX*-uy + relY*ux)
+	allowedHalfWidth := math.Max(0, (width/2)*(along/length))
+
+	switch {
+	case perp >= allowedHalfWidth+0.5:
+		return 0
+	case perp <= allowedHalfWidth-0.5:
+		return 1
+	default:
+		return allowedHalfWidth + 0.5 - perp
+	}
+}
+
+// forEachPixelNear visits every pixel within radius of (cx, cy) that lies
+// inside img's bounds.
+func forEachPixelNear(img *image.RGBA, cx, cy, radius float64, fn func(x, y int, px, py float64)) {
+	forEachPixelInBounds(img, cx-radius, cy-radius, cx+radius, cy+radius, fn)
+}
+
+// forEachPixelInBounds visits every pixel in the [minX,maxX]x[minY,maxY]
+// rectangle that lies inside img's bounds.
+func forEachPixelInBounds(img *image.RGBA, minX, minY, maxX, maxY float64, fn func(x, y int, px, py float64)) {
+	bounds := img.Bounds()
+
+	for y := int(minY); y <= int(maxY); y++ {
+		if y < bounds.Min.Y || y >= bounds.Max.Y {
+			continue
+		}
+
+		for x := int(minX); x <= int(maxX); x++ {
+			if x < bounds.Min.X || x >= bounds.Max.X {
+				continue
+			}
+
+			fn(x, y, float64(x)+0.5, float64(y)+0.5)
+		}
+	}
+}
+
+func blendPixel(img *image.RGBA, x, y int, c color.RGBA, coverage float64) {
+	alpha := float64(c.A) / 255 * coverage
+	if alpha <= 0 {
+		return
+	}
+
+	existing := img.RGBAAt(x, y)
+	blend := func(src, dst uint8) uint8 {
+		return uint8(float64(src)*alpha + float64(dst)*(1-alpha))
+	}
+
+	img.SetRGBA(x, y, color.RGBA{
+		R: blend(c.R, existing.R),
+		G: blend(c.G, existing.G),
+		B: blend(c.B, existing.B),
+		A: 0xff,
+	})
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+// renderCacheCapacity bounds how many encoded render responses are kept in
+// memory at once.
+const renderCacheCapacity = 256
+
+var renderCache, _ = lru.New[string, []byte](renderCacheCapacity)
+
+// cacheKey computes a stable hash of the normalized render request. It is
+// used both as the in-process render cache key and, quoted, as the HTTP
+// ETag, so identical (board, flip, size, theme, pieces, ...) tuples always
+// resolve to the same key regardless of query parameter order.
+func cacheKey(opts RenderOptions) string {
+	normalized := fmt.Sprintf("%s|%s|%t|%d|%s|%t|%s|%s|%s|%d|%s|%s",
+		opts.FEN, opts.LastMove, opts.Flip, opts.Size, opts.Theme, opts.Coords,
+		opts.Pieces, opts.Format, opts.PGN, opts.Delay, opts.Arrows, opts.Circles)
+
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
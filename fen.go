@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var fenPlacement = regexp.MustCompile(`^([rnbqkpRNBQKP1-8]{1,8}/){7}[rnbqkpRNBQKP1-8]{1,8}$`)
+var fenActiveColor = regexp.MustCompile(`^[wb]$`)
+var fenCastling = regexp.MustCompile(`^(-|K?Q?k?q?)$`)
+var fenEnPassant = regexp.MustCompile(`^(-|[a-h][36])$`)
+
+// CastlingRights records which castling moves are still available for each
+// side, as parsed from the castling availability field of a FEN string.
+type CastlingRights struct {
+	WhiteKingside  bool
+	WhiteQueenside bool
+	BlackKingside  bool
+	BlackQueenside bool
+}
+
+// FullBoard is a fully parsed FEN position: the piece placement plus the
+// remaining five FEN fields needed to render side-to-move, castling, and en
+// passant indicators.
+type FullBoard struct {
+	Board
+
+	ActiveColor    byte
+	Castling       CastlingRights
+	EnPassant      string
+	HalfmoveClock  int
+	FullmoveNumber int
+}
+
+// parseFEN parses a complete FEN string (all six space-separated fields)
+// into a FullBoard. Each field is validated independently so that the
+// returned error identifies precisely which field failed.
+func parseFEN(fen string) (*FullBoard, error) {
+	fields := strings.Fields(fen)
+	if len(fields) != 6 {
+		return nil, fmt.Errorf("invalid FEN: expected 6 space-separated fields, got %d", len(fields))
+	}
+
+	board, err := validate(fields[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if !fenActiveColor.MatchString(fields[1]) {
+		return nil, fmt.Errorf("invalid FEN: active color field must be 'w' or 'b', got %q", fields[1])
+	}
+
+	if !fenCastling.MatchString(fields[2]) {
+		return nil, fmt.Errorf("invalid FEN: castling availability field %q is malformed", fields[2])
+	}
+
+	if !fenEnPassant.MatchString(fields[3]) {
+		return nil, fmt.Errorf("invalid FEN: en passant target field %q is malformed", fields[3])
+	}
+
+	halfmove, err := strconv.Atoi(fields[4])
+	if err != nil || halfmove < 0 {
+		return nil, fmt.Errorf("invalid FEN: halfmove clock field %q is not a non-negative integer", fields[4])
+	}
+
+	fullmove, err := strconv.Atoi(fields[5])
+	if err != nil || fullmove < 1 {
+		return nil, fmt.Errorf("invalid FEN: fullmove number field %q is not a positive integer", fields[5])
+	}
+
+	enPassant := fields[3]
+	if enPassant == "-" {
+		enPassant = ""
+	}
+
+	return &FullBoard{
+		Board:          *board,
+		ActiveColor:    fields[1][0],
+		Castling:       parseCastling(fields[2]),
+		EnPassant:      enPassant,
+		HalfmoveClock:  halfmove,
+		FullmoveNumber: fullmove,
+	}, nil
+}
+
+func parseCastling(field string) CastlingRights {
+	return CastlingRights{
+		WhiteKingside:  strings.Contains(field, "K"),
+		WhiteQueenside: strings.Contains(field, "Q"),
+		BlackKingside:  strings.Contains(field, "k"),
+		BlackQueenside: strings.Contains(field, "q"),
+	}
+}
+
+// squareToCoords converts an algebraic square such as "e4" into the
+// (column, row) indices used by Board.Pieces, where row 0 is the 8th rank.
+func squareToCoords(square string) (column, row int, err error) {
+	if len(square) != 2 {
+		return 0, 0, fmt.Errorf("invalid square %q", square)
+	}
+
+	file := square[0]
+	rank := square[1]
+
+	if file < 'a' || file > 'h' || rank < '1' || rank > '8' {
+		return 0, 0, fmt.Errorf("invalid square %q", square)
+	}
+
+	column = int(file - 'a')
+	row = 8 - int(rank-'0')
+
+	return column, row, nil
+}
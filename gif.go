@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+)
+
+// defaultGIFDelay is the per-frame delay, in hundredths of a second, used
+// when ?delay= is not given.
+const defaultGIFDelay = 75
+
+// RenderGIF renders a PGN game (opts.PGN) move-by-move into an animated
+// GIF, one frame per position starting from opts.FEN (or the standard
+// starting position, if opts.FEN is empty).
+func RenderGIF(opts RenderOptions) (*gif.GIF, error) {
+	fen := opts.FEN
+	if fen == "" {
+		fen = startingPosition
+	}
+
+	state, err := newGameState(fen)
+	if err != nil {
+		return nil, err
+	}
+
+	delay := opts.Delay
+	if delay <= 0 {
+		delay = defaultGIFDelay
+	}
+
+	result := &gif.GIF{}
+
+	addFrame := func(lastMove string) error {
+		frameOpts := opts
+		frameOpts.LastMove = lastMove
+
+		rgba, err := renderPosition(state.toFullBoard(), frameOpts)
+		if err != nil {
+			return err
+		}
+
+		paletted := image.NewPaletted(rgba.Bounds(), palette.Plan9)
+		draw.Draw(paletted, paletted.Bounds(), rgba, image.Point{}, draw.Src)
+
+		result.Image = append(result.Image, paletted)
+		result.Delay = append(result.Delay, delay)
+
+		return nil
+	}
+
+	if err := addFrame(""); err != nil {
+		return nil, err
+	}
+
+	for _, san := range parsePGNMoves(opts.PGN) {
+		from, to, err := state.applyMove(san)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pgn: %w", err)
+		}
+
+		if err := addFrame(from + to); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
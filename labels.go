@@ -0,0 +1,82 @@
+package main
+
+import (
+	"image"
+	"image/color"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+func loadLabelFont() *opentype.Font {
+	data, err := static.ReadFile("static/font.ttf")
+	if err != nil {
+		panic(err)
+	}
+
+	f, err := opentype.Parse(data)
+	if err != nil {
+		panic(err)
+	}
+
+	return f
+}
+
+var labelFont = loadLabelFont()
+
+func labelFace(tileSize int) font.Face {
+	face, err := opentype.NewFace(labelFont, &opentype.FaceOptions{
+		Size:    float64(tileSize) / 5,
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return face
+}
+
+// drawCoordinateLabels draws file letters (a-h) along the bottom edge and
+// rank numbers (1-8) along the left edge of the board, oriented for the
+// given flip state.
+func drawCoordinateLabels(img *image.RGBA, flip bool, tileSize int, theme Theme) {
+	files := "abcdefgh"
+	ranks := "87654321"
+	if flip {
+		files = "hgfedcba"
+		ranks = "12345678"
+	}
+
+	drawer := &font.Drawer{
+		Dst:  img,
+		Face: labelFace(tileSize),
+	}
+
+	margin := tileSize / 10
+
+	for i := 0; i < 8; i++ {
+		// File labels sit on the bottom edge (screen row 7), rank labels on
+		// the left edge (screen column 0); pick whichever theme colour
+		// contrasts with the tile under each label rather than a single
+		// fixed colour, or half the labels land on a same-coloured tile.
+		drawer.Src = image.NewUniform(labelColor(7, i, theme))
+		drawer.Dot = fixed.P(i*tileSize+margin, tileSize*8-margin)
+		drawer.DrawString(string(files[i]))
+
+		drawer.Src = image.NewUniform(labelColor(i, 0, theme))
+		drawer.Dot = fixed.P(margin, i*tileSize+tileSize/4+margin)
+		drawer.DrawString(string(ranks[i]))
+	}
+}
+
+// labelColor returns the theme colour that contrasts with the tile at the
+// given screen row/column, matching the dark/light pattern renderPosition
+// draws tiles with.
+func labelColor(screenRow, screenColumn int, theme Theme) color.RGBA {
+	if (screenRow+screenColumn)%2 == 0 {
+		return theme.Light
+	}
+	return theme.Dark
+}
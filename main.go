@@ -1,55 +1,25 @@
 package main
 
 import (
+	"bytes"
 	"embed"
 	"fmt"
-	"image"
-	"image/color"
-	"image/draw"
+	"image/gif"
 	"image/png"
+	"net/http"
 	"os"
-	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/labstack/echo"
+
+	"github.com/vcokltfre/chess-board-renderer/pieceset"
 )
 
 //go:embed static
 var static embed.FS
 
-func loadImage(filename string) image.Image {
-	f, err := static.Open("static/" + filename)
-	if err != nil {
-		panic(err)
-	}
-
-	img, _, err := image.Decode(f)
-	if err != nil {
-		panic(err)
-	}
-
-	return img
-}
-
-var (
-	WhitePawnImage   = loadImage("pawn_white.png")
-	WhiteKnightImage = loadImage("knight_white.png")
-	WhiteBishopImage = loadImage("bishop_white.png")
-	WhiteRookImage   = loadImage("rook_white.png")
-	WhiteQueenImage  = loadImage("queen_white.png")
-	WhiteKingImage   = loadImage("king_white.png")
-	BlackPawnImage   = loadImage("pawn_black.png")
-	BlackKnightImage = loadImage("knight_black.png")
-	BlackBishopImage = loadImage("bishop_black.png")
-	BlackRookImage   = loadImage("rook_black.png")
-	BlackQueenImage  = loadImage("queen_black.png")
-	BlackKingImage   = loadImage("king_black.png")
-)
-
-var FEN = regexp.MustCompile(`^([rnbqkpRNBQKP1-8]{1,8}/){7}[rnbqkpRNBQKP1-8]{1,8}$`)
-
 type Piece int
 
 const (
@@ -68,19 +38,21 @@ const (
 	BlackKing
 )
 
-var pieceImages = map[Piece]image.Image{
-	WhitePawn:   WhitePawnImage,
-	WhiteKnight: WhiteKnightImage,
-	WhiteBishop: WhiteBishopImage,
-	WhiteRook:   WhiteRookImage,
-	WhiteQueen:  WhiteQueenImage,
-	WhiteKing:   WhiteKingImage,
-	BlackPawn:   BlackPawnImage,
-	BlackKnight: BlackKnightImage,
-	BlackBishop: BlackBishopImage,
-	BlackRook:   BlackRookImage,
-	BlackQueen:  BlackQueenImage,
-	BlackKing:   BlackKingImage,
+// spriteKeys maps each Piece to the sprite name a pieceset.Set serves it
+// under.
+var spriteKeys = map[Piece]pieceset.Piece{
+	WhitePawn:   pieceset.WhitePawn,
+	WhiteKnight: pieceset.WhiteKnight,
+	WhiteBishop: pieceset.WhiteBishop,
+	WhiteRook:   pieceset.WhiteRook,
+	WhiteQueen:  pieceset.WhiteQueen,
+	WhiteKing:   pieceset.WhiteKing,
+	BlackPawn:   pieceset.BlackPawn,
+	BlackKnight: pieceset.BlackKnight,
+	BlackBishop: pieceset.BlackBishop,
+	BlackRook:   pieceset.BlackRook,
+	BlackQueen:  pieceset.BlackQueen,
+	BlackKing:   pieceset.BlackKing,
 }
 
 var pieceChars = map[rune]Piece{
@@ -102,9 +74,11 @@ type Board struct {
 	Pieces [8][8]Piece
 }
 
+// validate parses the piece placement field of a FEN string (the part
+// before the first space) into a Board.
 func validate(board string) (*Board, error) {
-	if !FEN.MatchString(board) {
-		return nil, echo.NewHTTPError(400, "Invalid FEN")
+	if !fenPlacement.MatchString(board) {
+		return nil, fmt.Errorf("invalid FEN: piece placement field is malformed")
 	}
 
 	result := &Board{
@@ -128,7 +102,7 @@ func validate(board string) (*Board, error) {
 		}
 
 		if len(rowPieces) != 8 {
-			return nil, echo.NewHTTPError(400, "Invalid FEN")
+			return nil, fmt.Errorf("invalid FEN: rank %d does not sum to 8 squares", 8-row)
 		}
 
 		copy(result.Pieces[row][:], rowPieces)
@@ -137,59 +111,135 @@ func validate(board string) (*Board, error) {
 	return result, nil
 }
 
-func render(board string, c echo.Context) error {
+// respond serves the encoded render identified by opts and contentType,
+// consulting the render cache and falling back to encode on a miss, then
+// honouring If-None-Match once the render is known to have succeeded.
+// Since identical opts always produce byte-identical output, the cache key
+// doubles as a strong ETag.
+func respond(opts RenderOptions, contentType string, c echo.Context, encode func() ([]byte, error)) error {
 	start := time.Now()
 
-	b, err := validate(board)
-	if err != nil {
-		return c.String(400, err.Error())
+	key := cacheKey(opts)
+	etag := `"` + key + `"`
+
+	encoded, ok := renderCache.Get(key)
+	if !ok {
+		var err error
+		encoded, err = encode()
+		if err != nil {
+			return c.String(400, err.Error())
+		}
+
+		renderCache.Add(key, encoded)
 	}
 
-	img := image.NewRGBA(image.Rect(0, 0, 512, 512))
-	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
-
-	for row := 0; row < 8; row++ {
-		for column := 0; column < 8; column++ {
-			piece := b.Pieces[row][column]
-			tileColour := (row + column) % 2
-
-			if tileColour == 0 {
-				draw.Draw(img, image.Rect(column*64, row*64, (column*64)+64, (row*64)+64), image.NewUniform(color.RGBA{
-					R: 0x4f,
-					G: 0x4f,
-					B: 0x4f,
-					A: 0xff,
-				}), image.Point{}, draw.Src)
-			}
+	// Only advertise the render as cacheable once we know it actually
+	// succeeded, so a malformed request's error response never gets
+	// cached as a permanent, immutable result.
+	c.Response().Header().Set("ETag", etag)
+	c.Response().Header().Set("Cache-Control", "public, max-age=31536000, immutable")
 
-			if piece == Empty {
-				continue
-			}
+	if c.Request().Header.Get("If-None-Match") == etag {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	c.Response().Header().Set("X-Processing-Time", time.Since(start).String())
+
+	fmt.Printf("Rendered board in %s\n", time.Since(start))
+
+	return c.Blob(http.StatusOK, contentType, encoded)
+}
 
-			pieceImage := pieceImages[piece]
+// renderPNG runs opts through RenderBoard and serves the resulting PNG.
+func renderPNG(opts RenderOptions, c echo.Context) error {
+	return respond(opts, "image/png", c, func() ([]byte, error) {
+		img, err := RenderBoard(opts)
+		if err != nil {
+			return nil, err
+		}
 
-			draw.Draw(img, image.Rect(column*64, row*64, (column*64)+64, (row*64)+64), pieceImage, image.Point{}, draw.Over)
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, err
 		}
-	}
 
-	processingTime := time.Since(start)
+		return buf.Bytes(), nil
+	})
+}
 
-	c.Response().Header().Set("Content-Type", "image/png")
-	c.Response().Header().Set("X-Processing-Time", processingTime.String())
-	png.Encode(c.Response().Writer, img)
+// renderSVGResponse runs opts through RenderSVG and serves the resulting
+// document.
+func renderSVGResponse(opts RenderOptions, c echo.Context) error {
+	return respond(opts, "image/svg+xml", c, func() ([]byte, error) {
+		return RenderSVG(opts)
+	})
+}
 
-	fmt.Printf("Rendered board in %s\n", processingTime)
+// renderGIFResponse runs opts through RenderGIF and serves the resulting
+// animation.
+func renderGIFResponse(opts RenderOptions, c echo.Context) error {
+	return respond(opts, "image/gif", c, func() ([]byte, error) {
+		anim, err := RenderGIF(opts)
+		if err != nil {
+			return nil, err
+		}
 
-	return nil
+		var buf bytes.Buffer
+		if err := gif.EncodeAll(&buf, anim); err != nil {
+			return nil, err
+		}
+
+		return buf.Bytes(), nil
+	})
 }
 
 func main() {
 	e := echo.New()
 
 	e.GET("/render", func(c echo.Context) error {
-		board := c.QueryParam("board")
+		opts := RenderOptions{
+			FEN:      c.QueryParam("board"),
+			LastMove: c.QueryParam("lastmove"),
+			Flip:     c.QueryParam("flip") == "true",
+			Theme:    c.QueryParam("theme"),
+			Coords:   c.QueryParam("coords") == "true",
+			Pieces:   c.QueryParam("pieces"),
+			Format:   c.QueryParam("format"),
+			PGN:      c.QueryParam("pgn"),
+			Arrows:   c.QueryParam("arrows"),
+			Circles:  c.QueryParam("circles"),
+		}
+
+		if sizeParam := c.QueryParam("size"); sizeParam != "" {
+			size, err := strconv.Atoi(sizeParam)
+			if err != nil {
+				return c.String(400, "Invalid size parameter")
+			}
+			opts.Size = size
+		}
+
+		if delayParam := c.QueryParam("delay"); delayParam != "" {
+			delay, err := strconv.Atoi(delayParam)
+			if err != nil {
+				return c.String(400, "Invalid delay parameter")
+			}
+			opts.Delay = delay
+		}
+
+		switch opts.Format {
+		case "", "png":
+			return renderPNG(opts, c)
+		case "svg":
+			return renderSVGResponse(opts, c)
+		case "gif":
+			return renderGIFResponse(opts, c)
+		default:
+			return c.String(400, fmt.Sprintf("Unsupported format %q", opts.Format))
+		}
+	})
 
-		return render(board, c)
+	e.GET("/sets", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, pieceset.Names)
 	})
 
 	bind := ":8080"
@@ -0,0 +1,370 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// startingPosition is the standard starting position, in full FEN, used as
+// the base of a PGN-driven GIF when no ?board= is given.
+const startingPosition = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+// GameState is a board position together with the metadata needed both to
+// apply further SAN moves to it and to render it.
+type GameState struct {
+	Pieces      [8][8]Piece
+	ActiveColor byte
+	Castling    CastlingRights
+	EnPassant   string
+}
+
+// newGameState builds a GameState from a complete FEN string, matching
+// PNG/SVG's parsing so a GIF can start from any position, not just a fresh
+// board.
+func newGameState(fen string) (*GameState, error) {
+	board, err := parseFEN(fen)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GameState{
+		Pieces:      board.Pieces,
+		ActiveColor: board.ActiveColor,
+		Castling:    board.Castling,
+		EnPassant:   board.EnPassant,
+	}, nil
+}
+
+func (g *GameState) toFullBoard() *FullBoard {
+	return &FullBoard{
+		Board:       Board{Pieces: g.Pieces},
+		ActiveColor: g.ActiveColor,
+		Castling:    g.Castling,
+		EnPassant:   g.EnPassant,
+	}
+}
+
+var pgnMoveNumber = regexp.MustCompile(`^\d+\.+`)
+
+var pgnResults = map[string]bool{"1-0": true, "0-1": true, "1/2-1/2": true, "*": true}
+
+// parsePGNMoves splits PGN movetext into SAN move tokens, discarding move
+// numbers and result markers. Annotation glyphs ("!" "?") are left on their
+// tokens; applyMove strips trailing ones. Move numbers are
+// stripped as a prefix rather than requiring their own token, since PGN
+// exporters commonly glue them to the move itself (e.g. "1.e4" not "1. e4").
+func parsePGNMoves(pgn string) []string {
+	fields := strings.Fields(pgn)
+	moves := make([]string, 0, len(fields))
+
+	for _, field := range fields {
+		field = pgnMoveNumber.ReplaceAllString(field, "")
+		if field == "" || pgnResults[field] {
+			continue
+		}
+
+		moves = append(moves, field)
+	}
+
+	return moves
+}
+
+var sanMove = regexp.MustCompile(`^([NBRQK])?([a-h])?([1-8])?(x)?([a-h][1-8])(=([NBRQ]))?[+#]?$`)
+
+// applyMove parses a single SAN move (e.g. "Nf3", "exd5", "O-O", "e8=Q") and
+// applies it to g in place, returning the from/to squares of the move.
+func (g *GameState) applyMove(san string) (from, to string, err error) {
+	san = strings.TrimRight(san, "!?")
+
+	if san == "O-O" || san == "O-O-O" {
+		return g.applyCastle(san)
+	}
+
+	groups := sanMove.FindStringSubmatch(san)
+	if groups == nil {
+		return "", "", fmt.Errorf("could not parse move %q", san)
+	}
+
+	pieceLetter, disambFile, disambRank, capture, dest, promotion := groups[1], groups[2], groups[3], groups[4], groups[5], groups[7]
+
+	destColumn, destRow, err := squareToCoords(dest)
+	if err != nil {
+		return "", "", err
+	}
+
+	isPawnMove := pieceLetter == ""
+	piece := g.pieceFor(pieceLetter)
+
+	fromColumn, fromRow, err := g.findSource(piece, isPawnMove, disambFile, disambRank, destColumn, destRow, capture != "")
+	if err != nil {
+		return "", "", fmt.Errorf("move %q: %w", san, err)
+	}
+
+	g.move(fromColumn, fromRow, destColumn, destRow, promotion)
+
+	from = squareName(fromColumn, fromRow)
+	to = dest
+	g.ActiveColor = opponentColor(g.ActiveColor)
+
+	return from, to, nil
+}
+
+func (g *GameState) applyCastle(san string) (from, to string, err error) {
+	rank := "1"
+	if g.ActiveColor == 'b' {
+		rank = "8"
+	}
+
+	kingFrom := "e" + rank
+	kingTo := "g" + rank
+	rookFrom := "h" + rank
+	rookTo := "f" + rank
+	if san == "O-O-O" {
+		kingTo = "c" + rank
+		rookFrom = "a" + rank
+		rookTo = "d" + rank
+	}
+
+	kfc, kfr, _ := squareToCoords(kingFrom)
+	ktc, ktr, _ := squareToCoords(kingTo)
+	rfc, rfr, _ := squareToCoords(rookFrom)
+	rtc, rtr, _ := squareToCoords(rookTo)
+
+	g.move(kfc, kfr, ktc, ktr, "")
+	g.move(rfc, rfr, rtc, rtr, "")
+
+	if g.ActiveColor == 'w' {
+		g.Castling.WhiteKingside = false
+		g.Castling.WhiteQueenside = false
+	} else {
+		g.Castling.BlackKingside = false
+		g.Castling.BlackQueenside = false
+	}
+
+	g.EnPassant = ""
+	g.ActiveColor = opponentColor(g.ActiveColor)
+
+	return kingFrom, kingTo, nil
+}
+
+func (g *GameState) pieceFor(letter string) Piece {
+	white := g.ActiveColor == 'w'
+
+	switch letter {
+	case "N":
+		if white {
+			return WhiteKnight
+		}
+		return BlackKnight
+	case "B":
+		if white {
+			return WhiteBishop
+		}
+		return BlackBishop
+	case "R":
+		if white {
+			return WhiteRook
+		}
+		return BlackRook
+	case "Q":
+		if white {
+			return WhiteQueen
+		}
+		return BlackQueen
+	case "K":
+		if white {
+			return WhiteKing
+		}
+		return BlackKing
+	default:
+		if white {
+			return WhitePawn
+		}
+		return BlackPawn
+	}
+}
+
+// findSource locates the single piece of the active color that can legally
+// reach (destColumn, destRow), narrowing candidates using the SAN
+// disambiguation hints when present. Move legality is limited to movement
+// pattern and a clear path; it does not check for pins or leaving the king
+// in check, which is sufficient for rendering a game's moves.
+func (g *GameState) findSource(piece Piece, isPawnMove bool, disambFile, disambRank string, destColumn, destRow int, isCapture bool) (column, row int, err error) {
+	var candidates [][2]int
+
+	for r := 0; r < 8; r++ {
+		for c := 0; c < 8; c++ {
+			if g.Pieces[r][c] != piece {
+				continue
+			}
+
+			if disambFile != "" && c != int(disambFile[0]-'a') {
+				continue
+			}
+			if disambRank != "" && r != 8-int(disambRank[0]-'0') {
+				continue
+			}
+
+			if isPawnMove {
+				if g.pawnReaches(c, r, destColumn, destRow, isCapture) {
+					candidates = append(candidates, [2]int{c, r})
+				}
+				continue
+			}
+
+			if g.pieceReaches(piece, c, r, destColumn, destRow) {
+				candidates = append(candidates, [2]int{c, r})
+			}
+		}
+	}
+
+	if len(candidates) == 0 {
+		return 0, 0, fmt.Errorf("no piece can reach %s", squareName(destColumn, destRow))
+	}
+
+	if len(candidates) > 1 {
+		return 0, 0, fmt.Errorf("move is ambiguous: %d pieces can reach %s", len(candidates), squareName(destColumn, destRow))
+	}
+
+	return candidates[0][0], candidates[0][1], nil
+}
+
+func (g *GameState) pawnReaches(fromColumn, fromRow, destColumn, destRow int, isCapture bool) bool {
+	direction := -1
+	if g.ActiveColor == 'b' {
+		direction = 1
+	}
+
+	if isCapture {
+		return fromRow+direction == destRow && abs(fromColumn-destColumn) == 1
+	}
+
+	if fromColumn != destColumn {
+		return false
+	}
+
+	if fromRow+direction == destRow {
+		return true
+	}
+
+	startRow := 6
+	if g.ActiveColor == 'b' {
+		startRow = 1
+	}
+
+	return fromRow == startRow && fromRow+2*direction == destRow && g.Pieces[fromRow+direction][fromColumn] == Empty
+}
+
+func (g *GameState) pieceReaches(piece Piece, fromColumn, fromRow, destColumn, destRow int) bool {
+	dc := destColumn - fromColumn
+	dr := destRow - fromRow
+
+	switch piece {
+	case WhiteKnight, BlackKnight:
+		return (abs(dc) == 1 && abs(dr) == 2) || (abs(dc) == 2 && abs(dr) == 1)
+	case WhiteKing, BlackKing:
+		return abs(dc) <= 1 && abs(dr) <= 1
+	case WhiteBishop, BlackBishop:
+		return abs(dc) == abs(dr) && g.pathClear(fromColumn, fromRow, destColumn, destRow)
+	case WhiteRook, BlackRook:
+		return (dc == 0 || dr == 0) && g.pathClear(fromColumn, fromRow, destColumn, destRow)
+	case WhiteQueen, BlackQueen:
+		return (dc == 0 || dr == 0 || abs(dc) == abs(dr)) && g.pathClear(fromColumn, fromRow, destColumn, destRow)
+	default:
+		return false
+	}
+}
+
+func (g *GameState) pathClear(fromColumn, fromRow, destColumn, destRow int) bool {
+	stepC := sign(destColumn - fromColumn)
+	stepR := sign(destRow - fromRow)
+
+	c, r := fromColumn+stepC, fromRow+stepR
+	for c != destColumn || r != destRow {
+		if g.Pieces[r][c] != Empty {
+			return false
+		}
+		c += stepC
+		r += stepR
+	}
+
+	return true
+}
+
+// move relocates the piece at (fromColumn, fromRow) to (destColumn,
+// destRow), handling en passant captures and pawn promotion, and updates
+// castling rights and the en passant target square for the position that
+// results.
+func (g *GameState) move(fromColumn, fromRow, destColumn, destRow int, promotion string) {
+	piece := g.Pieces[fromRow][fromColumn]
+
+	isPawn := piece == WhitePawn || piece == BlackPawn
+	isEnPassantCapture := isPawn && destColumn != fromColumn && g.Pieces[destRow][destColumn] == Empty
+	if isEnPassantCapture {
+		g.Pieces[fromRow][destColumn] = Empty
+	}
+
+	g.Pieces[fromRow][fromColumn] = Empty
+	g.Pieces[destRow][destColumn] = piece
+
+	if promotion != "" {
+		g.Pieces[destRow][destColumn] = g.pieceFor(promotion)
+	}
+
+	g.EnPassant = ""
+	if isPawn && abs(destRow-fromRow) == 2 {
+		g.EnPassant = squareName(fromColumn, (fromRow+destRow)/2)
+	}
+
+	g.updateCastlingRights(fromColumn, fromRow)
+	g.updateCastlingRights(destColumn, destRow)
+}
+
+func (g *GameState) updateCastlingRights(column, row int) {
+	switch squareName(column, row) {
+	case "e1":
+		g.Castling.WhiteKingside = false
+		g.Castling.WhiteQueenside = false
+	case "e8":
+		g.Castling.BlackKingside = false
+		g.Castling.BlackQueenside = false
+	case "h1":
+		g.Castling.WhiteKingside = false
+	case "a1":
+		g.Castling.WhiteQueenside = false
+	case "h8":
+		g.Castling.BlackKingside = false
+	case "a8":
+		g.Castling.BlackQueenside = false
+	}
+}
+
+func squareName(column, row int) string {
+	return fmt.Sprintf("%c%d", 'a'+column, 8-row)
+}
+
+func opponentColor(c byte) byte {
+	if c == 'w' {
+		return 'b'
+	}
+	return 'w'
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func sign(n int) int {
+	switch {
+	case n > 0:
+		return 1
+	case n < 0:
+		return -1
+	default:
+		return 0
+	}
+}
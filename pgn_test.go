@@ -0,0 +1,135 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestParsePGNMoves checks that move numbers are stripped whether or not
+// they're glued to the move that follows, matching the formatting used by
+// common PGN exporters as well as the more spaced-out style.
+func TestParsePGNMoves(t *testing.T) {
+	tests := []struct {
+		name string
+		pgn  string
+		want []string
+	}{
+		{
+			name: "glued move numbers",
+			pgn:  "1.e4 e5 2.Nf3 Nc6 3.Bb5 1-0",
+			want: []string{"e4", "e5", "Nf3", "Nc6", "Bb5"},
+		},
+		{
+			name: "spaced move numbers",
+			pgn:  "1. e4 e5 2. Nf3 Nc6 3. Bb5 *",
+			want: []string{"e4", "e5", "Nf3", "Nc6", "Bb5"},
+		},
+		{
+			name: "glued black move numbers",
+			pgn:  "1.e4 e5 2.Nf3 Nc6 3.Bb5 3...a6 4.Ba4 4...Nf6 5.O-O 5...Be7 6.Re1 6...b5 7.Bb3 7...d6",
+			want: []string{"e4", "e5", "Nf3", "Nc6", "Bb5", "a6", "Ba4", "Nf6", "O-O", "Be7", "Re1", "b5", "Bb3", "d6"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parsePGNMoves(tt.pgn)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parsePGNMoves(%q) = %v, want %v", tt.pgn, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestApplyMove_OperaGame replays Morphy's Opera Game (1858) move by move,
+// exercising captures, checks, disambiguation, and castling against a full
+// real game rather than synthetic positions.
+func TestApplyMove_OperaGame(t *testing.T) {
+	moves := []string{
+		"e4", "e5", "Nf3", "d6", "d4", "Bg4", "dxe5", "Bxf3",
+		"Qxf3", "dxe5", "Bc4", "Nf6", "Qb3", "Qe7", "Nc3", "c6",
+		"Bg5", "b5", "Nxb5", "cxb5", "Bxb5+", "Nbd7", "O-O-O", "Rd8",
+		"Rxd7", "Rxd7", "Rd1", "Qe6", "Bxd7+", "Nxd7", "Qb8+", "Nxb8",
+		"Rd8#",
+	}
+
+	state, err := newGameState(startingPosition)
+	if err != nil {
+		t.Fatalf("newGameState: %v", err)
+	}
+
+	for i, san := range moves {
+		if _, _, err := state.applyMove(san); err != nil {
+			t.Fatalf("move %d (%s): %v", i+1, san, err)
+		}
+
+		// White's 12.O-O-O must move the king to c1 and the a1 rook to d1;
+		// check this right after the move, since the d1 rook is later
+		// traded off and the square reused by White's other rook.
+		if san == "O-O-O" {
+			if state.Pieces[7][2] != WhiteKing {
+				t.Errorf("expected white king on c1 after O-O-O, got %v", state.Pieces[7][2])
+			}
+			if state.Pieces[7][3] != WhiteRook {
+				t.Errorf("expected white rook on d1 after O-O-O, got %v", state.Pieces[7][3])
+			}
+			if state.Castling.WhiteKingside || state.Castling.WhiteQueenside {
+				t.Errorf("expected white to have lost all castling rights after O-O-O, got %+v", state.Castling)
+			}
+		}
+	}
+}
+
+// TestApplyMove_EnPassant checks that a pawn captured en passant is removed
+// from its actual square, not the (empty) destination square.
+func TestApplyMove_EnPassant(t *testing.T) {
+	state, err := newGameState(startingPosition)
+	if err != nil {
+		t.Fatalf("newGameState: %v", err)
+	}
+
+	for _, san := range []string{"e4", "a6", "e5", "d5"} {
+		if _, _, err := state.applyMove(san); err != nil {
+			t.Fatalf("move %s: %v", san, err)
+		}
+	}
+
+	if _, _, err := state.applyMove("exd6"); err != nil {
+		t.Fatalf("exd6: %v", err)
+	}
+
+	if state.Pieces[2][3] != WhitePawn { // d6
+		t.Errorf("expected white pawn on d6 after en passant capture, got %v", state.Pieces[2][3])
+	}
+	if state.Pieces[3][3] != Empty { // d5, where the captured black pawn stood
+		t.Errorf("expected d5 to be empty after en passant capture, got %v", state.Pieces[3][3])
+	}
+}
+
+// TestApplyMove_Promotion checks that a pawn reaching the last rank is
+// replaced by the promoted piece.
+func TestApplyMove_Promotion(t *testing.T) {
+	state := &GameState{ActiveColor: 'w'}
+	state.Pieces[1][4] = WhitePawn // e7
+
+	if _, _, err := state.applyMove("e8=Q"); err != nil {
+		t.Fatalf("e8=Q: %v", err)
+	}
+
+	if state.Pieces[0][4] != WhiteQueen {
+		t.Errorf("expected white queen on e8 after promotion, got %v", state.Pieces[0][4])
+	}
+}
+
+// TestApplyMove_AmbiguousWithoutDisambiguation checks that a SAN move which
+// fails to disambiguate between multiple legal candidates is rejected
+// rather than silently resolved to an arbitrary piece.
+func TestApplyMove_AmbiguousWithoutDisambiguation(t *testing.T) {
+	state := &GameState{ActiveColor: 'w'}
+	state.Pieces[7][1] = WhiteKnight // b1
+	state.Pieces[7][5] = WhiteKnight // f1
+
+	if _, _, err := state.applyMove("Nd2"); err == nil {
+		t.Fatal("expected an error for an ambiguous move lacking disambiguation, got nil")
+	}
+}
@@ -0,0 +1,83 @@
+package pieceset
+
+import (
+	"container/list"
+	"image"
+	"sync"
+
+	"golang.org/x/image/draw"
+)
+
+// spriteCacheCapacity bounds how many resized sprites are kept in memory at
+// once, across all sets and tile sizes.
+const spriteCacheCapacity = 512
+
+type spriteKey struct {
+	set      string
+	piece    Piece
+	tileSize int
+}
+
+// spriteLRU is a bounded least-recently-used cache of resized sprites, keyed
+// by (set, piece, tileSize).
+type spriteLRU struct {
+	mu       sync.Mutex
+	order    *list.List
+	entries  map[spriteKey]*list.Element
+	capacity int
+}
+
+type spriteEntry struct {
+	key    spriteKey
+	sprite image.Image
+}
+
+var spriteCache = &spriteLRU{
+	order:    list.New(),
+	entries:  make(map[spriteKey]*list.Element),
+	capacity: spriteCacheCapacity,
+}
+
+// get returns the sprite for key, resizing source to tileSize and caching
+// the result on a miss.
+func (c *spriteLRU) get(set string, piece Piece, tileSize int, source image.Image) image.Image {
+	key := spriteKey{set: set, piece: piece, tileSize: tileSize}
+
+	c.mu.Lock()
+	if el, ok := c.entries[key]; ok {
+		c.order.MoveToFront(el)
+		sprite := el.Value.(*spriteEntry).sprite
+		c.mu.Unlock()
+		return sprite
+	}
+	c.mu.Unlock()
+
+	sprite := resize(source, tileSize)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		c.order.MoveToFront(el)
+		return el.Value.(*spriteEntry).sprite
+	}
+
+	el := c.order.PushFront(&spriteEntry{key: key, sprite: sprite})
+	c.entries[key] = el
+
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		if oldest != nil {
+			c.order.Remove(oldest)
+			delete(c.entries, oldest.Value.(*spriteEntry).key)
+		}
+	}
+
+	return sprite
+}
+
+func resize(source image.Image, tileSize int) image.Image {
+	dst := image.NewRGBA(image.Rect(0, 0, tileSize, tileSize))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), source, source.Bounds(), draw.Over, nil)
+	return dst
+}
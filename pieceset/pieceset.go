@@ -0,0 +1,98 @@
+// Package pieceset loads named collections of piece sprites (e.g.
+// "cburnett", "merida", "alpha") and serves them resized to a requested tile
+// size, caching the resized sprites so repeated renders at common sizes
+// don't re-scale on every request.
+package pieceset
+
+import (
+	"embed"
+	"fmt"
+	"image"
+	_ "image/png"
+)
+
+//go:embed sets
+var sets embed.FS
+
+// Piece identifies one of the twelve piece/colour sprites every set must
+// provide.
+type Piece string
+
+const (
+	WhitePawn   Piece = "pawn_white"
+	WhiteKnight Piece = "knight_white"
+	WhiteBishop Piece = "bishop_white"
+	WhiteRook   Piece = "rook_white"
+	WhiteQueen  Piece = "queen_white"
+	WhiteKing   Piece = "king_white"
+	BlackPawn   Piece = "pawn_black"
+	BlackKnight Piece = "knight_black"
+	BlackBishop Piece = "bishop_black"
+	BlackRook   Piece = "rook_black"
+	BlackQueen  Piece = "queen_black"
+	BlackKing   Piece = "king_black"
+)
+
+var allPieces = []Piece{
+	WhitePawn, WhiteKnight, WhiteBishop, WhiteRook, WhiteQueen, WhiteKing,
+	BlackPawn, BlackKnight, BlackBishop, BlackRook, BlackQueen, BlackKing,
+}
+
+// Default is the set used when a requested name is empty or unrecognised.
+const Default = "cburnett"
+
+// Names lists the piece sets bundled with the server, in the order they
+// should be presented (e.g. by GET /sets).
+var Names = []string{"cburnett", "merida", "alpha"}
+
+// Set is a loaded collection of full-resolution piece sprites. Resized
+// sprites are kept in the package-wide sprite cache, not in the Set.
+type Set struct {
+	name   string
+	images map[Piece]image.Image
+}
+
+var loaded = loadAll()
+
+func loadAll() map[string]*Set {
+	result := make(map[string]*Set, len(Names))
+
+	for _, name := range Names {
+		images := make(map[Piece]image.Image, len(allPieces))
+
+		for _, piece := range allPieces {
+			f, err := sets.Open(fmt.Sprintf("sets/%s/%s.png", name, piece))
+			if err != nil {
+				panic(err)
+			}
+
+			img, _, err := image.Decode(f)
+			if err != nil {
+				panic(err)
+			}
+
+			images[piece] = img
+		}
+
+		result[name] = &Set{name: name, images: images}
+	}
+
+	return result
+}
+
+// Load returns the named piece set, falling back to Default if name is
+// empty or not one of Names.
+func Load(name string) *Set {
+	if set, ok := loaded[name]; ok {
+		return set
+	}
+
+	return loaded[Default]
+}
+
+// Image returns the sprite for piece scaled to tileSize, using the
+// process-wide resize cache so repeated requests at the same size are free
+// after the first.
+func (s *Set) Image(piece Piece, tileSize int) image.Image {
+	return spriteCache.get(s.name, piece, tileSize, s.images[piece])
+}
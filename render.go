@@ -0,0 +1,287 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/vcokltfre/chess-board-renderer/pieceset"
+)
+
+// defaultBoardSize is the output image size, in pixels, used when ?size= is
+// not given.
+const defaultBoardSize = 512
+
+// minTileSize is the smallest tile size RenderBoard will produce, so that a
+// pathologically small ?size= doesn't divide down to nothing.
+const minTileSize = 8
+
+// maxBoardSize is the largest output image size, in pixels, RenderBoard will
+// produce, so that a pathologically large ?size= can't be used to force a
+// huge image.NewRGBA allocation.
+const maxBoardSize = 2048
+
+// RenderOptions configures how a position is rendered to an image. It is
+// built from query parameters by the /render handler and consumed by
+// RenderBoard.
+type RenderOptions struct {
+	FEN      string
+	LastMove string
+	Flip     bool
+	Size     int
+	Theme    string
+	Coords   bool
+	Pieces   string
+	Format   string
+	PGN      string
+	Delay    int
+	Arrows   string
+	Circles  string
+}
+
+// layout resolves opts into the concrete dimensions and assets a position
+// is rendered with.
+type layout struct {
+	tileSize  int
+	boardSize int
+	theme     Theme
+	pieces    *pieceset.Set
+}
+
+func resolveLayout(opts RenderOptions) layout {
+	theme, ok := Themes[opts.Theme]
+	if !ok {
+		theme = Themes[DefaultTheme]
+	}
+
+	size := opts.Size
+	if size <= 0 {
+		size = defaultBoardSize
+	}
+	if size > maxBoardSize {
+		size = maxBoardSize
+	}
+
+	tileSize := size / 8
+	if tileSize < minTileSize {
+		tileSize = minTileSize
+	}
+
+	return layout{
+		tileSize:  tileSize,
+		boardSize: tileSize * 8,
+		theme:     theme,
+		pieces:    pieceset.Load(opts.Pieces),
+	}
+}
+
+// RenderBoard renders the position and options described by opts to an
+// image. It performs no I/O itself, which keeps it easy to exercise outside
+// of an HTTP request.
+func RenderBoard(opts RenderOptions) (image.Image, error) {
+	b, err := parseFEN(opts.FEN)
+	if err != nil {
+		return nil, err
+	}
+
+	return renderPosition(b, opts)
+}
+
+// renderPosition renders an already-parsed position, so that callers such
+// as the GIF encoder that synthesise positions from PGN moves don't have to
+// round-trip them through FEN.
+func renderPosition(b *FullBoard, opts RenderOptions) (*image.RGBA, error) {
+	l := resolveLayout(opts)
+
+	img := image.NewRGBA(image.Rect(0, 0, l.boardSize, l.boardSize))
+	draw.Draw(img, img.Bounds(), image.NewUniform(l.theme.Light), image.Point{}, draw.Src)
+
+	for screenRow := 0; screenRow < 8; screenRow++ {
+		for screenColumn := 0; screenColumn < 8; screenColumn++ {
+			column, row := fromScreen(screenColumn, screenRow, opts.Flip)
+			piece := b.Pieces[row][column]
+
+			if (screenRow+screenColumn)%2 == 0 {
+				draw.Draw(img, tileRect(screenColumn, screenRow, l.tileSize), image.NewUniform(l.theme.Dark), image.Point{}, draw.Src)
+			}
+
+			if piece == Empty {
+				continue
+			}
+
+			sprite := l.pieces.Image(spriteKeys[piece], l.tileSize)
+			draw.Draw(img, tileRect(screenColumn, screenRow, l.tileSize), sprite, image.Point{}, draw.Over)
+		}
+	}
+
+	if err := drawLastMoveHighlight(img, opts.LastMove, opts.Flip, l.tileSize); err != nil {
+		return nil, err
+	}
+
+	drawEnPassantIndicator(img, b, opts.Flip, l.tileSize)
+	drawCastlingIndicators(img, b, opts.Flip, l.tileSize)
+	drawSideToMoveIndicator(img, b, opts.Flip, l.tileSize, l.boardSize)
+
+	if opts.Coords {
+		drawCoordinateLabels(img, opts.Flip, l.tileSize, l.theme)
+	}
+
+	circles, err := parseCircles(opts.Circles)
+	if err != nil {
+		return nil, err
+	}
+
+	arrows, err := parseArrows(opts.Arrows)
+	if err != nil {
+		return nil, err
+	}
+
+	drawAnnotations(img, circles, arrows, opts.Flip, l.tileSize)
+
+	return img, nil
+}
+
+// tileRect returns the pixel bounds of the tile at the given screen
+// column/row.
+func tileRect(screenColumn, screenRow, tileSize int) image.Rectangle {
+	return image.Rect(screenColumn*tileSize, screenRow*tileSize, (screenColumn+1)*tileSize, (screenRow+1)*tileSize)
+}
+
+// fromScreen maps a screen column/row back to the corresponding Board.Pieces
+// column/row, undoing the mirroring applied when flip is set.
+func fromScreen(screenColumn, screenRow int, flip bool) (column, row int) {
+	if flip {
+		return 7 - screenColumn, 7 - screenRow
+	}
+	return screenColumn, screenRow
+}
+
+// toScreen maps a Board.Pieces column/row to its screen position.
+func toScreen(column, row int, flip bool) (screenColumn, screenRow int) {
+	if flip {
+		return 7 - column, 7 - row
+	}
+	return column, row
+}
+
+// drawLastMoveHighlight paints translucent yellow overlays over the from and
+// to squares of the lastmove query parameter (e.g. "e2e4"), if one was given.
+func drawLastMoveHighlight(img *image.RGBA, lastMove string, flip bool, tileSize int) error {
+	if lastMove == "" {
+		return nil
+	}
+
+	if len(lastMove) != 4 {
+		return fmt.Errorf("invalid lastmove %q: expected a from/to square pair such as e2e4", lastMove)
+	}
+
+	highlight := image.NewUniform(color.RGBA{R: 0xff, G: 0xeb, B: 0x3b, A: 0x80})
+
+	for _, square := range []string{lastMove[:2], lastMove[2:]} {
+		column, row, err := squareToCoords(square)
+		if err != nil {
+			return fmt.Errorf("invalid lastmove %q: %w", lastMove, err)
+		}
+
+		screenColumn, screenRow := toScreen(column, row, flip)
+		draw.Draw(img, tileRect(screenColumn, screenRow, tileSize), highlight, image.Point{}, draw.Over)
+	}
+
+	return nil
+}
+
+// drawEnPassantIndicator shades the en passant target square, if the
+// position has one.
+func drawEnPassantIndicator(img *image.RGBA, b *FullBoard, flip bool, tileSize int) {
+	if b.EnPassant == "" {
+		return
+	}
+
+	column, row, err := squareToCoords(b.EnPassant)
+	if err != nil {
+		return
+	}
+
+	screenColumn, screenRow := toScreen(column, row, flip)
+	shade := image.NewUniform(color.RGBA{R: 0x4c, G: 0xaf, B: 0x50, A: 0x80})
+	draw.Draw(img, tileRect(screenColumn, screenRow, tileSize), shade, image.Point{}, draw.Over)
+}
+
+var castlingCorners = []struct {
+	square   string
+	hasRight func(CastlingRights) bool
+}{
+	{"h1", func(c CastlingRights) bool { return c.WhiteKingside }},
+	{"a1", func(c CastlingRights) bool { return c.WhiteQueenside }},
+	{"h8", func(c CastlingRights) bool { return c.BlackKingside }},
+	{"a8", func(c CastlingRights) bool { return c.BlackQueenside }},
+}
+
+// drawCastlingIndicators crosses out the corner square of any rook whose
+// castling right has already been lost.
+func drawCastlingIndicators(img *image.RGBA, b *FullBoard, flip bool, tileSize int) {
+	for _, corner := range castlingCorners {
+		if corner.hasRight(b.Castling) {
+			continue
+		}
+
+		column, row, err := squareToCoords(corner.square)
+		if err != nil {
+			continue
+		}
+
+		screenColumn, screenRow := toScreen(column, row, flip)
+		drawCross(img, screenColumn, screenRow, tileSize, color.RGBA{R: 0xd3, G: 0x2f, B: 0x2f, A: 0xc0})
+	}
+}
+
+// drawCross draws a diagonal cross over the tile at the given screen
+// column/row.
+func drawCross(img *image.RGBA, screenColumn, screenRow, tileSize int, c color.Color) {
+	left := screenColumn * tileSize
+	top := screenRow * tileSize
+	thickness := tileSize / 20
+	if thickness < 1 {
+		thickness = 1
+	}
+
+	for i := 0; i < tileSize; i++ {
+		for t := -thickness / 2; t <= thickness/2; t++ {
+			img.Set(left+i, top+i+t, c)
+			img.Set(left+i, top+(tileSize-1-i)+t, c)
+		}
+	}
+}
+
+// drawSideToMoveIndicator draws a small triangle on the screen edge
+// corresponding to the side whose turn it is to move.
+func drawSideToMoveIndicator(img *image.RGBA, b *FullBoard, flip bool, tileSize, boardSize int) {
+	markerSize := tileSize / 5
+	if markerSize < 4 {
+		markerSize = 4
+	}
+	c := color.RGBA{R: 0x21, G: 0x21, B: 0x21, A: 0xff}
+
+	_, whiteScreenRow := toScreen(0, 7, flip)
+	markerAtBottom := whiteScreenRow > 3
+	if b.ActiveColor != 'w' {
+		markerAtBottom = !markerAtBottom
+	}
+
+	top := 0
+	if markerAtBottom {
+		top = boardSize - markerSize
+	}
+
+	for y := 0; y < markerSize; y++ {
+		width := markerSize - y
+		if !markerAtBottom {
+			width = y + 1
+		}
+
+		for x := 0; x < width; x++ {
+			img.Set(boardSize/2-width/2+x, top+y, c)
+		}
+	}
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+)
+
+// RenderSVG renders the position and options described by opts to an SVG
+// document. Tiles are drawn as <rect> elements, which scale cleanly, and
+// pieces as embedded base64 PNG sprites resized to the tile size.
+func RenderSVG(opts RenderOptions) ([]byte, error) {
+	b, err := parseFEN(opts.FEN)
+	if err != nil {
+		return nil, err
+	}
+
+	l := resolveLayout(opts)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`,
+		l.boardSize, l.boardSize, l.boardSize, l.boardSize)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="%s"/>`, l.boardSize, l.boardSize, hexColor(l.theme.Light))
+
+	for screenRow := 0; screenRow < 8; screenRow++ {
+		for screenColumn := 0; screenColumn < 8; screenColumn++ {
+			if (screenRow+screenColumn)%2 != 0 {
+				continue
+			}
+
+			fmt.Fprintf(&buf, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`,
+				screenColumn*l.tileSize, screenRow*l.tileSize, l.tileSize, l.tileSize, hexColor(l.theme.Dark))
+		}
+	}
+
+	for screenRow := 0; screenRow < 8; screenRow++ {
+		for screenColumn := 0; screenColumn < 8; screenColumn++ {
+			column, row := fromScreen(screenColumn, screenRow, opts.Flip)
+			piece := b.Pieces[row][column]
+			if piece == Empty {
+				continue
+			}
+
+			dataURI, err := pngDataURI(l.pieces.Image(spriteKeys[piece], l.tileSize))
+			if err != nil {
+				return nil, err
+			}
+
+			fmt.Fprintf(&buf, `<image x="%d" y="%d" width="%d" height="%d" href="%s"/>`,
+				screenColumn*l.tileSize, screenRow*l.tileSize, l.tileSize, l.tileSize, dataURI)
+		}
+	}
+
+	if opts.LastMove != "" {
+		if err := svgLastMoveHighlight(&buf, opts.LastMove, opts.Flip, l.tileSize); err != nil {
+			return nil, err
+		}
+	}
+
+	svgEnPassantIndicator(&buf, b, opts.Flip, l.tileSize)
+	svgCastlingIndicators(&buf, b, opts.Flip, l.tileSize)
+	svgSideToMoveIndicator(&buf, b, opts.Flip, l.tileSize, l.boardSize)
+
+	if opts.Coords {
+		svgCoordinateLabels(&buf, opts.Flip, l.tileSize, l.theme)
+	}
+
+	circles, err := parseCircles(opts.Circles)
+	if err != nil {
+		return nil, err
+	}
+
+	arrows, err := parseArrows(opts.Arrows)
+	if err != nil {
+		return nil, err
+	}
+
+	svgAnnotations(&buf, circles, arrows, opts.Flip, l.tileSize)
+
+	buf.WriteString(`</svg>`)
+
+	return buf.Bytes(), nil
+}
+
+func svgLastMoveHighlight(buf *bytes.Buffer, lastMove string, flip bool, tileSize int) error {
+	if len(lastMove) != 4 {
+		return fmt.Errorf("invalid lastmove %q: expected a from/to square pair such as e2e4", lastMove)
+	}
+
+	for _, square := range []string{lastMove[:2], lastMove[2:]} {
+		column, row, err := squareToCoords(square)
+		if err != nil {
+			return fmt.Errorf("invalid lastmove %q: %w", lastMove, err)
+		}
+
+		screenColumn, screenRow := toScreen(column, row, flip)
+		fmt.Fprintf(buf, `<rect x="%d" y="%d" width="%d" height="%d" fill="#ffeb3b" fill-opacity="0.5"/>`,
+			screenColumn*tileSize, screenRow*tileSize, tileSize, tileSize)
+	}
+
+	return nil
+}
+
+// svgEnPassantIndicator shades the en passant target square, if the
+// position has one, matching drawEnPassantIndicator's raster output.
+func svgEnPassantIndicator(buf *bytes.Buffer, b *FullBoard, flip bool, tileSize int) {
+	if b.EnPassant == "" {
+		return
+	}
+
+	column, row, err := squareToCoords(b.EnPassant)
+	if err != nil {
+		return
+	}
+
+	screenColumn, screenRow := toScreen(column, row, flip)
+	fmt.Fprintf(buf, `<rect x="%d" y="%d" width="%d" height="%d" fill="#4caf50" fill-opacity="0.5"/>`,
+		screenColumn*tileSize, screenRow*tileSize, tileSize, tileSize)
+}
+
+// svgCastlingIndicators crosses out the corner square of any rook whose
+// castling right has already been lost, matching drawCastlingIndicators.
+func svgCastlingIndicators(buf *bytes.Buffer, b *FullBoard, flip bool, tileSize int) {
+	for _, corner := range castlingCorners {
+		if corner.hasRight(b.Castling) {
+			continue
+		}
+
+		column, row, err := squareToCoords(corner.square)
+		if err != nil {
+			continue
+		}
+
+		screenColumn, screenRow := toScreen(column, row, flip)
+		left := screenColumn * tileSize
+		top := screenRow * tileSize
+		thickness := tileSize / 20
+		if thickness < 1 {
+			thickness = 1
+		}
+
+		fmt.Fprintf(buf, `<g stroke="#d32f2f" stroke-opacity="0.75" stroke-width="%d">`, thickness)
+		fmt.Fprintf(buf, `<line x1="%d" y1="%d" x2="%d" y2="%d"/>`, left, top, left+tileSize, top+tileSize)
+		fmt.Fprintf(buf, `<line x1="%d" y1="%d" x2="%d" y2="%d"/>`, left, top+tileSize, left+tileSize, top)
+		buf.WriteString(`</g>`)
+	}
+}
+
+// svgSideToMoveIndicator draws a small triangle on the board edge
+// corresponding to the side whose turn it is to move, matching
+// drawSideToMoveIndicator.
+func svgSideToMoveIndicator(buf *bytes.Buffer, b *FullBoard, flip bool, tileSize, boardSize int) {
+	markerSize := tileSize / 5
+	if markerSize < 4 {
+		markerSize = 4
+	}
+
+	_, whiteScreenRow := toScreen(0, 7, flip)
+	markerAtBottom := whiteScreenRow > 3
+	if b.ActiveColor != 'w' {
+		markerAtBottom = !markerAtBottom
+	}
+
+	top := 0
+	bottom := markerSize
+	if markerAtBottom {
+		top = boardSize - markerSize
+		bottom = boardSize
+	}
+
+	left := boardSize/2 - markerSize/2
+	right := boardSize/2 + markerSize/2
+
+	apexY := top
+	if markerAtBottom {
+		apexY = bottom
+	}
+	baseY := bottom
+	if markerAtBottom {
+		baseY = top
+	}
+
+	fmt.Fprintf(buf, `<polygon points="%d,%d %d,%d %d,%d" fill="#212121"/>`,
+		left, baseY, right, baseY, boardSize/2, apexY)
+}
+
+// svgCoordinateLabels draws file letters (a-h) along the bottom edge and
+// rank numbers (1-8) along the left edge of the board, oriented for the
+// given flip state, matching drawCoordinateLabels.
+func svgCoordinateLabels(buf *bytes.Buffer, flip bool, tileSize int, theme Theme) {
+	files := "abcdefgh"
+	ranks := "87654321"
+	if flip {
+		files = "hgfedcba"
+		ranks = "12345678"
+	}
+
+	fontSize := tileSize / 5
+	margin := tileSize / 10
+
+	for i := 0; i < 8; i++ {
+		fmt.Fprintf(buf, `<text x="%d" y="%d" font-size="%d" fill="%s">%c</text>`,
+			i*tileSize+margin, tileSize*8-margin, fontSize, hexColor(labelColor(7, i, theme)), files[i])
+
+		fmt.Fprintf(buf, `<text x="%d" y="%d" font-size="%d" fill="%s">%c</text>`,
+			margin, i*tileSize+tileSize/4+margin, fontSize, hexColor(labelColor(i, 0, theme)), ranks[i])
+	}
+}
+
+// svgAnnotations draws circles then arrows over the board, in screen space,
+// matching drawAnnotations.
+func svgAnnotations(buf *bytes.Buffer, circles []Circle, arrows []Arrow, flip bool, tileSize int) {
+	for _, circle := range circles {
+		column, row, err := squareToCoords(circle.Square)
+		if err != nil {
+			continue
+		}
+
+		screenColumn, screenRow := toScreen(column, row, flip)
+		cx, cy := tileCenter(screenColumn, screenRow, tileSize)
+		radius := float64(tileSize) * 0.44
+		thickness := float64(tileSize) * 0.06
+
+		fmt.Fprintf(buf, `<circle cx="%.2f" cy="%.2f" r="%.2f" fill="none" stroke="%s" stroke-opacity="%.3f" stroke-width="%.2f"/>`,
+			cx, cy, radius, hexColor(circle.Color), float64(circle.Color.A)/255, thickness)
+	}
+
+	for _, arrow := range arrows {
+		fromColumn, fromRow, err := squareToCoords(arrow.From)
+		if err != nil {
+			continue
+		}
+
+		toColumn, toRow, err := squareToCoords(arrow.To)
+		if err != nil {
+			continue
+		}
+
+		fsc, fsr := toScreen(fromColumn, fromRow, flip)
+		tsc, tsr := toScreen(toColumn, toRow, flip)
+		svgArrow(buf, fsc, fsr, tsc, tsr, tileSize, arrow.Color)
+	}
+}
+
+// svgArrow draws a shaft with a filled triangular head pointing from the
+// center of the from tile to the center of the to tile, matching drawArrow.
+func svgArrow(buf *bytes.Buffer, fromScreenColumn, fromScreenRow, toScreenColumn, toScreenRow, tileSize int, c color.RGBA) {
+	x0, y0 := tileCenter(fromScreenColumn, fromScreenRow, tileSize)
+	x1, y1 := tileCenter(toScreenColumn, toScreenRow, tileSize)
+
+	length := math.Hypot(x1-x0, y1-y0)
+	if length == 0 {
+		return
+	}
+
+	ux, uy := (x1-x0)/length, (y1-y0)/length
+	headLength := float64(tileSize) * 0.35
+	headWidth := float64(tileSize) * 0.28
+	shaftWidth := float64(tileSize) * 0.12
+
+	startX, startY := x0+ux*float64(tileSize)*0.15, y0+uy*float64(tileSize)*0.15
+	shaftEndX, shaftEndY := x1-ux*headLength, y1-uy*headLength
+
+	opacity := float64(c.A) / 255
+	fmt.Fprintf(buf, `<line x1="%.2f" y1="%.2f" x2="%.2f" y2="%.2f" stroke="%s" stroke-opacity="%.3f" stroke-width="%.2f"/>`,
+		startX, startY, shaftEndX, shaftEndY, hexColor(c), opacity, shaftWidth)
+
+	perpX, perpY := -uy, ux
+	baseX, baseY := shaftEndX, shaftEndY
+	leftX, leftY := baseX+perpX*headWidth/2, baseY+perpY*headWidth/2
+	rightX, rightY := baseX-perpX*headWidth/2, baseY-perpY*headWidth/2
+
+	fmt.Fprintf(buf, `<polygon points="%.2f,%.2f %.2f,%.2f %.2f,%.2f" fill="%s" fill-opacity="%.3f"/>`,
+		x1, y1, leftX, leftY, rightX, rightY, hexColor(c), opacity)
+}
+
+func pngDataURI(img image.Image) (string, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", err
+	}
+
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
@@ -0,0 +1,34 @@
+package main
+
+import "image/color"
+
+// Theme holds the light/dark tile colours used to render a board.
+type Theme struct {
+	Light color.RGBA
+	Dark  color.RGBA
+}
+
+// DefaultTheme is used when the ?theme= query parameter is absent or
+// unrecognised.
+const DefaultTheme = "gray"
+
+// Themes holds the available named tile colour schemes, selectable via the
+// ?theme= query parameter.
+var Themes = map[string]Theme{
+	"gray": {
+		Light: color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+		Dark:  color.RGBA{R: 0x4f, G: 0x4f, B: 0x4f, A: 0xff},
+	},
+	"brown": {
+		Light: color.RGBA{R: 0xf0, G: 0xd9, B: 0xb5, A: 0xff},
+		Dark:  color.RGBA{R: 0xb5, G: 0x88, B: 0x63, A: 0xff},
+	},
+	"blue": {
+		Light: color.RGBA{R: 0xde, G: 0xe3, B: 0xe6, A: 0xff},
+		Dark:  color.RGBA{R: 0x8c, G: 0xa2, B: 0xad, A: 0xff},
+	},
+	"green": {
+		Light: color.RGBA{R: 0xee, G: 0xee, B: 0xd2, A: 0xff},
+		Dark:  color.RGBA{R: 0x76, G: 0x96, B: 0x56, A: 0xff},
+	},
+}